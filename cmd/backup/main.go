@@ -53,7 +53,32 @@ var (
 	restoreInterval = flag.Duration("restore-interval", 3*time.Minute, "the restore interval")
 	dbname          = flag.String("dbname", "test", "name of database to test")
 	retryLimit      = flag.Int("retry-limit", 200, "retry count")
-	backupURI       = flag.String("backup-uri", "local:///tmp/backup", "where the backup file should in")
+	backupURI       = flag.String("backup-uri", "local:///tmp/backup", "where the backup file should in, used when storage-scheme is local")
+
+	maxBackupsInChain = flag.Int("max-backups-in-chain", 0, "max full/incremental pieces kept before starting a fresh backup chain, <= 0 means unlimited")
+	retentionDuration = flag.Duration("retention-duration", 0, "max age of the oldest piece before starting a fresh backup chain, <= 0 means unlimited")
+
+	storageScheme         = flag.String("storage-scheme", "local", "remote storage backend, support values: local / s3 / gcs / azblob, default value: local")
+	storageBucket         = flag.String("storage-bucket", "", "bucket/container name for the remote storage backend")
+	storagePrefix         = flag.String("storage-prefix", "backup", "path prefix inside the bucket/container for the remote storage backend")
+	storageRegion         = flag.String("storage-region", "", "region for the remote storage backend, only used by s3")
+	storageEndpoint       = flag.String("storage-endpoint", "", "endpoint override for the remote storage backend")
+	storageAccessKey      = flag.String("storage-access-key", "", "access key / credentials file / account name for the remote storage backend")
+	storageSecretKey      = flag.String("storage-secret-key", "", "secret key / account key for the remote storage backend")
+	storageForcePathStyle = flag.Bool("storage-force-path-style", false, "use path-style addressing, only used by s3")
+	storageSSE            = flag.String("storage-sse", "", "server-side encryption algorithm, only used by s3")
+	storageSSEKMSKeyID    = flag.String("storage-sse-kms-key-id", "", "SSE-KMS key id, only used by s3")
+	storageRoleARN        = flag.String("storage-role-arn", "", "IAM role to assume, only used by s3")
+
+	tuningRateLimitMBpsMin  = flag.Uint64("tuning-rate-limit-mb-min", 0, "lower bound of the randomized BACKUP/RESTORE RATE_LIMIT, in MB/s")
+	tuningRateLimitMBpsMax  = flag.Uint64("tuning-rate-limit-mb-max", 0, "upper bound of the randomized BACKUP/RESTORE RATE_LIMIT, in MB/s; 0 disables RATE_LIMIT tuning")
+	tuningConcurrencyMin    = flag.Uint("tuning-concurrency-min", 0, "lower bound of the randomized BACKUP/RESTORE CONCURRENCY")
+	tuningConcurrencyMax    = flag.Uint("tuning-concurrency-max", 0, "upper bound of the randomized BACKUP/RESTORE CONCURRENCY; 0 disables CONCURRENCY tuning")
+	tuningRandomizeChecksum = flag.Bool("tuning-randomize-checksum", false, "flip BACKUP/RESTORE CHECKSUM on/off randomly instead of leaving it at BR's default")
+
+	pitr               = flag.Bool("pitr", false, "run in point-in-time-recovery mode: restore to a random historical commit ts via log backup instead of replaying the full/incremental chain")
+	pitrRingSize       = flag.Int("pitr-ring-size", 0, "max historical snapshots kept for PITR restores to pick from, <= 0 uses the built-in default")
+	pitrSampleInterval = flag.Duration("pitr-sample-interval", 0, "how often to record a PITR snapshot, <= 0 uses the built-in default")
 
 	pessimistic = flag.Bool("pessimistic", true, "use pessimistic transaction")
 	replicaRead = flag.String("tidb-replica-read", "leader", "tidb_replica_read mode, support values: leader / follower / leader-and-follower, default value: leader.")
@@ -76,14 +101,39 @@ func main() {
 		Provider: cluster.NewDefaultClusterProvider(),
 		ClientCreator: backup.ClientCreator{
 			Cfg: backup.Config{
-				NumAccounts:     *accounts,
-				BackupInterval:  *backupInterval,
-				RestoreInterval: *restoreInterval,
-				Concurrency:     *concurrency,
-				RetryLimit:      *retryLimit,
-				Contention:      *contention,
-				DbName:          *dbname,
-				BackupURI:       *backupURI,
+				NumAccounts:       *accounts,
+				BackupInterval:    *backupInterval,
+				RestoreInterval:   *restoreInterval,
+				Concurrency:       *concurrency,
+				RetryLimit:        *retryLimit,
+				Contention:        *contention,
+				DbName:            *dbname,
+				BackupURI:         *backupURI,
+				MaxBackupsInChain: *maxBackupsInChain,
+				RetentionDuration: *retentionDuration,
+				Tuning: backup.BackupTuning{
+					RateLimitMBpsMin:  *tuningRateLimitMBpsMin,
+					RateLimitMBpsMax:  *tuningRateLimitMBpsMax,
+					ConcurrencyMin:    uint32(*tuningConcurrencyMin),
+					ConcurrencyMax:    uint32(*tuningConcurrencyMax),
+					RandomizeChecksum: *tuningRandomizeChecksum,
+				},
+				PITR:               *pitr,
+				PITRRingSize:       *pitrRingSize,
+				PITRSampleInterval: *pitrSampleInterval,
+				StorageBackend: backup.StorageBackend{
+					Scheme:         *storageScheme,
+					Bucket:         *storageBucket,
+					Prefix:         *storagePrefix,
+					Region:         *storageRegion,
+					Endpoint:       *storageEndpoint,
+					AccessKey:      *storageAccessKey,
+					SecretKey:      *storageSecretKey,
+					ForcePathStyle: *storageForcePathStyle,
+					SSE:            *storageSSE,
+					SSEKMSKeyID:    *storageSSEKMSKeyID,
+					RoleARN:        *storageRoleARN,
+				},
 			},
 			Features: backup.Features{
 				Pessimistic: *pessimistic,